@@ -0,0 +1,251 @@
+package main
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "strings"
+
+    "github.com/jackc/pgx/v4"
+)
+
+// bulkRowSource adapts rows already read into memory to pgx.CopyFromSource,
+// so they can be streamed into a COPY without re-querying the source.
+type bulkRowSource struct {
+    rows [][]interface{}
+    idx  int
+}
+
+func (s *bulkRowSource) Next() bool {
+    s.idx++
+    return s.idx <= len(s.rows)
+}
+
+func (s *bulkRowSource) Values() ([]interface{}, error) {
+    return s.rows[s.idx-1], nil
+}
+
+func (s *bulkRowSource) Err() error {
+    return nil
+}
+
+// bulkSupportedTypes lists the pg_type names pgx's COPY binary format is
+// known to round-trip reliably. Anything else (custom enums, composite
+// types, domains over exotic base types, etc.) falls back to the
+// per-row path instead of risking a CopyFrom failure partway through.
+var bulkSupportedTypes = map[string]bool{
+    "int2": true, "int4": true, "int8": true,
+    "text": true, "varchar": true, "bpchar": true, "name": true,
+    "bool": true,
+    "float4": true, "float8": true, "numeric": true,
+    "date": true, "timestamp": true, "timestamptz": true, "time": true, "timetz": true,
+    "uuid": true, "bytea": true,
+    "json": true, "jsonb": true,
+    "interval": true, "inet": true, "cidr": true, "macaddr": true,
+}
+
+// unsupportedBulkColumns cross-references the table's pg_catalog column
+// types against bulkSupportedTypes and reports which of the columns
+// being synced aren't on the known-safe list.
+func unsupportedBulkColumns(types []columnType, filteredColNames []string) []string {
+    wanted := make(map[string]bool, len(filteredColNames))
+    for _, c := range filteredColNames {
+        wanted[c] = true
+    }
+    var unsupported []string
+    for _, t := range types {
+        if !wanted[t.name] {
+            continue
+        }
+        if !bulkSupportedTypes[t.typ] {
+            unsupported = append(unsupported, fmt.Sprintf("%s (%s)", t.name, t.typ))
+        }
+    }
+    return unsupported
+}
+
+// bulkUpsert handles keysToInsert+keysToUpsert via COPY instead of one
+// SELECT+INSERT per row: it pulls every changed row out of source with
+// VALUES-joined SELECTs, one per chunkKeys batch (the same technique
+// syncTableInTx's target query uses), COPYs them into a temp staging table on target, then does
+// a single INSERT ... SELECT ... ON CONFLICT DO UPDATE out of staging.
+// It returns an error (without side effects beyond the temp table) if any
+// column's type can't round-trip through COPY's binary path, so the
+// caller can fall back to the per-row path.
+func bulkUpsert(ctx context.Context, source *pgx.Conn, tx pgx.Tx, schema, tableName string, filteredColNames, pkCols []string, keys []string, keyValues *rowKeys, verbose bool, logger *log.Logger) error {
+    if len(keys) == 0 {
+        return nil
+    }
+
+    // syncTableInTx's joinIdentifiers call quotes filteredColNames in
+    // place; the type check and CopyFrom need the bare column names.
+    bareColNames := make([]string, len(filteredColNames))
+    for i, col := range filteredColNames {
+        bareColNames[i] = strings.Trim(col, `"`)
+    }
+
+    columnTypes, err := getColumnTypesCatalog(ctx, source, schema, tableName)
+    if err != nil {
+        return fmt.Errorf("error resolving column types for bulk copy: %w", err)
+    }
+    if unsupported := unsupportedBulkColumns(columnTypes, bareColNames); len(unsupported) > 0 {
+        return fmt.Errorf("table %s.%s has columns that can't round-trip through COPY's binary path: %v", schema, tableName, unsupported)
+    }
+
+    selectCols := joinIdentifiers(append([]string(nil), filteredColNames...))
+
+    keyAliasCols := make([]string, len(pkCols))
+    joinConditions := make([]string, len(pkCols))
+    for i, col := range pkCols {
+        keyAliasCols[i] = fmt.Sprintf("k%d", i+1)
+        joinConditions[i] = fmt.Sprintf("t.%s = k.%s", quoteIdentifier(col), keyAliasCols[i])
+    }
+
+    rows := make([][]interface{}, 0, len(keys))
+    for _, batch := range chunkKeys(keys, len(pkCols)) {
+        args := make([]interface{}, 0, len(batch)*len(pkCols))
+        valueRows := make([]string, len(batch))
+        n := 1
+        for i, key := range batch {
+            pkValues := keyValues.Get(key)
+            placeholders := make([]string, len(pkValues))
+            for j, v := range pkValues {
+                placeholders[j] = fmt.Sprintf("$%d", n)
+                args = append(args, v)
+                n++
+            }
+            valueRows[i] = "(" + strings.Join(placeholders, ", ") + ")"
+        }
+
+        selectQuery := fmt.Sprintf(
+            "SELECT %s FROM %s.%s t JOIN (VALUES %s) AS k(%s) ON %s",
+            selectCols, quoteIdentifier(schema), quoteIdentifier(tableName),
+            strings.Join(valueRows, ", "), strings.Join(keyAliasCols, ", "), strings.Join(joinConditions, " AND "),
+        )
+        if verbose {
+            logger.Printf("Bulk source select: %s", selectQuery)
+        }
+
+        sourceRows, err := source.Query(ctx, selectQuery, args...)
+        if err != nil {
+            return fmt.Errorf("error querying source rows for bulk copy: %w", err)
+        }
+
+        for sourceRows.Next() {
+            values := make([]interface{}, len(filteredColNames))
+            pointers := make([]interface{}, len(filteredColNames))
+            for i := range values {
+                pointers[i] = &values[i]
+            }
+            if err := sourceRows.Scan(pointers...); err != nil {
+                sourceRows.Close()
+                return fmt.Errorf("error scanning source row data for bulk copy: %w", err)
+            }
+            rows = append(rows, values)
+        }
+        err = sourceRows.Err()
+        sourceRows.Close()
+        if err != nil {
+            return fmt.Errorf("error reading source rows for bulk copy: %w", err)
+        }
+    }
+
+    // Schema-qualify the stage name: SyncSubset can sync several specs
+    // with the same table name in different schemas inside one
+    // transaction, and a bare "t_stage_<table>" would collide with
+    // ON COMMIT DROP keeping the first one alive until commit.
+    stageTable := fmt.Sprintf("t_stage_%s_%s", schema, tableName)
+    createStage := fmt.Sprintf(
+        "CREATE TEMP TABLE %s (LIKE %s.%s INCLUDING DEFAULTS) ON COMMIT DROP",
+        quoteIdentifier(stageTable), quoteIdentifier(schema), quoteIdentifier(tableName),
+    )
+    if verbose {
+        logger.Printf("Bulk stage: %s", createStage)
+    }
+    if _, err := tx.Exec(ctx, createStage); err != nil {
+        return fmt.Errorf("error creating staging table: %w", err)
+    }
+
+    copyCount, err := tx.CopyFrom(
+        ctx,
+        pgx.Identifier{stageTable},
+        bareColNames,
+        &bulkRowSource{rows: rows},
+    )
+    if err != nil {
+        return fmt.Errorf("error copying rows into staging table: %w", err)
+    }
+    if verbose {
+        logger.Printf("Copied %d rows into %s", copyCount, stageTable)
+    }
+
+    updateSet := make([]string, len(filteredColNames))
+    for i, col := range filteredColNames {
+        updateSet[i] = fmt.Sprintf("%s = EXCLUDED.%s", quoteIdentifier(col), quoteIdentifier(col))
+    }
+
+    insertQuery := fmt.Sprintf(
+        "INSERT INTO %s.%s (%s) SELECT %s FROM %s ON CONFLICT (%s) DO UPDATE SET %s",
+        quoteIdentifier(schema), quoteIdentifier(tableName),
+        selectCols, selectCols, quoteIdentifier(stageTable),
+        joinIdentifiers(append([]string(nil), pkCols...)),
+        strings.Join(updateSet, ", "),
+    )
+    if verbose {
+        logger.Printf("Bulk upsert: %s", insertQuery)
+    }
+    if _, err := tx.Exec(ctx, insertQuery); err != nil {
+        return fmt.Errorf("error upserting from staging table: %w", err)
+    }
+
+    return nil
+}
+
+// bulkDelete removes rows whose primary key is in keys via one
+// DELETE ... USING (VALUES ...) statement per chunkKeys batch instead of
+// one DELETE per row.
+func bulkDelete(ctx context.Context, tx pgx.Tx, schema, tableName string, pkCols []string, keys []string, keyValues *rowKeys, verbose bool, logger *log.Logger) error {
+    if len(keys) == 0 {
+        return nil
+    }
+
+    aliasCols := make([]string, len(pkCols))
+    conditions := make([]string, len(pkCols))
+    for i, col := range pkCols {
+        aliasCols[i] = fmt.Sprintf("c%d", i+1)
+        conditions[i] = fmt.Sprintf("%s.%s = d.%s", quoteIdentifier(tableName), quoteIdentifier(col), aliasCols[i])
+    }
+
+    for _, batch := range chunkKeys(keys, len(pkCols)) {
+        args := make([]interface{}, 0, len(batch)*len(pkCols))
+        valueRows := make([]string, len(batch))
+        n := 1
+        for i, key := range batch {
+            pkValues := keyValues.Get(key)
+            placeholders := make([]string, len(pkValues))
+            for j, v := range pkValues {
+                placeholders[j] = fmt.Sprintf("$%d", n)
+                args = append(args, v)
+                n++
+            }
+            valueRows[i] = "(" + strings.Join(placeholders, ", ") + ")"
+        }
+
+        deleteQuery := fmt.Sprintf(
+            "DELETE FROM %s.%s USING (VALUES %s) AS d(%s) WHERE %s",
+            quoteIdentifier(schema), quoteIdentifier(tableName),
+            strings.Join(valueRows, ", "),
+            strings.Join(aliasCols, ", "),
+            strings.Join(conditions, " AND "),
+        )
+
+        if verbose {
+            logger.Printf("Bulk delete: %s", deleteQuery)
+        }
+
+        if _, err := tx.Exec(ctx, deleteQuery, args...); err != nil {
+            return fmt.Errorf("error bulk deleting rows from target: %w", err)
+        }
+    }
+    return nil
+}
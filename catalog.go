@@ -0,0 +1,163 @@
+package main
+
+import (
+    "context"
+    "fmt"
+
+    "github.com/jackc/pgx/v4"
+)
+
+// getPrimaryKeyColumnsCatalog looks up a table's primary key directly via
+// pg_index/pg_attribute instead of information_schema.table_constraints.
+// Some managed/replica Postgres instances don't expose
+// information_schema.table_constraints rows for tables the connecting
+// role doesn't own, even when pg_catalog is readable, so this is the
+// fallback used by --read-only-source.
+func getPrimaryKeyColumnsCatalog(ctx context.Context, conn *pgx.Conn, schema, table string) ([]string, error) {
+    query := `
+        SELECT a.attname
+        FROM pg_index i
+        JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey)
+        WHERE i.indrelid = $1::regclass AND i.indisprimary
+        ORDER BY array_position(i.indkey, a.attnum)
+    `
+    rows, err := conn.Query(ctx, query, qualifiedName(schema, table))
+    if err != nil {
+        return nil, fmt.Errorf("error querying pg_index for primary key: %w", err)
+    }
+    defer rows.Close()
+
+    var pkCols []string
+    for rows.Next() {
+        var colName string
+        if err := rows.Scan(&colName); err != nil {
+            return nil, fmt.Errorf("error scanning pg_index row: %w", err)
+        }
+        pkCols = append(pkCols, colName)
+    }
+    return pkCols, rows.Err()
+}
+
+// getColumnNamesCatalog lists a table's non-dropped columns straight from
+// pg_attribute, in physical (attnum) order, for use when
+// information_schema.columns isn't trustworthy for the connecting role.
+func getColumnNamesCatalog(ctx context.Context, conn *pgx.Conn, schema, table string) ([]string, error) {
+    query := `
+        SELECT a.attname
+        FROM pg_attribute a
+        WHERE a.attrelid = $1::regclass
+          AND a.attnum > 0
+          AND NOT a.attisdropped
+        ORDER BY a.attnum
+    `
+    rows, err := conn.Query(ctx, query, qualifiedName(schema, table))
+    if err != nil {
+        return nil, fmt.Errorf("error querying pg_attribute for columns: %w", err)
+    }
+    defer rows.Close()
+
+    var colNames []string
+    for rows.Next() {
+        var colName string
+        if err := rows.Scan(&colName); err != nil {
+            return nil, fmt.Errorf("error scanning pg_attribute row: %w", err)
+        }
+        colNames = append(colNames, colName)
+    }
+    return colNames, rows.Err()
+}
+
+// columnType describes a column's Postgres type for callers (such as the
+// COPY-based bulk path) that need to know types ahead of time rather than
+// relying on information_schema.
+type columnType struct {
+    name   string
+    typeID uint32
+    typ    string
+}
+
+// getColumnTypesCatalog resolves each column's pg_type via pg_attribute,
+// in physical column order, using pg_catalog only.
+func getColumnTypesCatalog(ctx context.Context, conn *pgx.Conn, schema, table string) ([]columnType, error) {
+    query := `
+        SELECT a.attname, a.atttypid, t.typname
+        FROM pg_attribute a
+        JOIN pg_type t ON t.oid = a.atttypid
+        WHERE a.attrelid = $1::regclass
+          AND a.attnum > 0
+          AND NOT a.attisdropped
+        ORDER BY a.attnum
+    `
+    rows, err := conn.Query(ctx, query, qualifiedName(schema, table))
+    if err != nil {
+        return nil, fmt.Errorf("error querying pg_type for columns: %w", err)
+    }
+    defer rows.Close()
+
+    var types []columnType
+    for rows.Next() {
+        var ct columnType
+        if err := rows.Scan(&ct.name, &ct.typeID, &ct.typ); err != nil {
+            return nil, fmt.Errorf("error scanning pg_type row: %w", err)
+        }
+        types = append(types, ct)
+    }
+    return types, rows.Err()
+}
+
+// getForeignKeysCatalog is the pg_catalog equivalent of getForeignKeys,
+// reading pg_constraint directly instead of
+// information_schema.referential_constraints.
+func getForeignKeysCatalog(ctx context.Context, conn *pgx.Conn) ([]fkConstraint, error) {
+    query := `
+        SELECT
+            cn.nspname, cl.relname, array_agg(ca.attname ORDER BY u.ord),
+            pn.nspname, pl.relname, array_agg(pa.attname ORDER BY u.ord)
+        FROM pg_constraint c
+        JOIN unnest(c.conkey, c.confkey) WITH ORDINALITY AS u(conkey, confkey, ord) ON true
+        JOIN pg_class cl ON cl.oid = c.conrelid
+        JOIN pg_namespace cn ON cn.oid = cl.relnamespace
+        JOIN pg_attribute ca ON ca.attrelid = c.conrelid AND ca.attnum = u.conkey
+        JOIN pg_class pl ON pl.oid = c.confrelid
+        JOIN pg_namespace pn ON pn.oid = pl.relnamespace
+        JOIN pg_attribute pa ON pa.attrelid = c.confrelid AND pa.attnum = u.confkey
+        WHERE c.contype = 'f'
+        GROUP BY c.oid, cn.nspname, cl.relname, pn.nspname, pl.relname
+    `
+    rows, err := conn.Query(ctx, query)
+    if err != nil {
+        return nil, fmt.Errorf("error querying pg_constraint for foreign keys: %w", err)
+    }
+    defer rows.Close()
+
+    var fks []fkConstraint
+    for rows.Next() {
+        var fk fkConstraint
+        if err := rows.Scan(&fk.childSchema, &fk.childTable, &fk.childCols, &fk.parentSchema, &fk.parentTable, &fk.parentCols); err != nil {
+            return nil, fmt.Errorf("error scanning pg_constraint row: %w", err)
+        }
+        fks = append(fks, fk)
+    }
+    return fks, rows.Err()
+}
+
+// resolvePrimaryKeyColumns finds a table's primary key, preferring an
+// explicit --pk-columns override, then falling back through
+// information_schema or (with --read-only-source) straight to
+// pg_index/pg_attribute for roles that can't see
+// information_schema.table_constraints rows for tables they don't own.
+func resolvePrimaryKeyColumns(ctx context.Context, conn *pgx.Conn, schema, table string, readOnlySource bool, pkColumnsOverride []string) ([]string, error) {
+    if len(pkColumnsOverride) > 0 {
+        return pkColumnsOverride, nil
+    }
+    if readOnlySource {
+        return getPrimaryKeyColumnsCatalog(ctx, conn, schema, table)
+    }
+    return getPrimaryKeyColumns(ctx, conn, schema, table)
+}
+
+// qualifiedName renders schema.table (each identifier quoted) for use as
+// a ::regclass cast argument.
+func qualifiedName(schema, table string) string {
+    return quoteIdentifier(schema) + "." + quoteIdentifier(table)
+}
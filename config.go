@@ -0,0 +1,137 @@
+package main
+
+import (
+    "fmt"
+    "os"
+    "strings"
+
+    "gopkg.in/yaml.v3"
+)
+
+// defaultSkipColumns are excluded from every table's sync regardless of
+// config, matching the tool's historical behavior of always dropping
+// generated tsvector caches.
+var defaultSkipColumns = []string{"search_vector"}
+
+// syncConfig holds the per-table force-sync and skip-column policy built
+// up from repeatable --force-table/--skip-columns flags and an optional
+// config file, replacing the old single global --force boolean.
+type syncConfig struct {
+    ForceTables map[tableRef]bool   `yaml:"-"`
+    SkipColumns map[tableRef][]string `yaml:"-"`
+
+    Force       []string            `yaml:"force,omitempty"`
+    Skip        map[string][]string `yaml:"skip_columns,omitempty"`
+}
+
+// newSyncConfig builds a syncConfig from repeatable CLI flag values.
+func newSyncConfig(forceTables, skipColumns []string) (*syncConfig, error) {
+    cfg := &syncConfig{
+        ForceTables: make(map[tableRef]bool),
+        SkipColumns: make(map[tableRef][]string),
+    }
+    if err := cfg.addForceTables(forceTables); err != nil {
+        return nil, err
+    }
+    if err := cfg.addSkipColumns(skipColumns); err != nil {
+        return nil, err
+    }
+    return cfg, nil
+}
+
+// loadConfigFile reads a YAML config file of the form:
+//
+//	force:
+//	  - public.lookup_codes
+//	skip_columns:
+//	  public.users: [search_vector, last_seen_at]
+//
+// and merges it into cfg.
+func loadConfigFile(path string, cfg *syncConfig) error {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return fmt.Errorf("error reading config file %s: %w", path, err)
+    }
+    var file syncConfig
+    if err := yaml.Unmarshal(data, &file); err != nil {
+        return fmt.Errorf("error parsing config file %s: %w", path, err)
+    }
+    if err := cfg.addForceTables(file.Force); err != nil {
+        return err
+    }
+    for table, cols := range file.Skip {
+        if err := cfg.addSkipColumns([]string{table + ":" + strings.Join(cols, ",")}); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// addForceTables merges "schema.table" entries (each possibly
+// comma-separated, the flag being repeatable) into the force set.
+func (c *syncConfig) addForceTables(raw []string) error {
+    for _, group := range raw {
+        for _, entry := range strings.Split(group, ",") {
+            entry = strings.TrimSpace(entry)
+            if entry == "" {
+                continue
+            }
+            schema, table := splitSchemaTable(entry)
+            c.ForceTables[tableRef{schema: schema, table: table}] = true
+        }
+    }
+    return nil
+}
+
+// addSkipColumns merges "schema.table:col1,col2" entries into the
+// per-table skip-column set.
+func (c *syncConfig) addSkipColumns(raw []string) error {
+    for _, entry := range raw {
+        entry = strings.TrimSpace(entry)
+        if entry == "" {
+            continue
+        }
+        idx := strings.Index(entry, ":")
+        if idx == -1 {
+            return fmt.Errorf("--skip-columns entry %q must be schema.table:col1,col2", entry)
+        }
+        schema, table := splitSchemaTable(entry[:idx])
+        ref := tableRef{schema: schema, table: table}
+        for _, col := range strings.Split(entry[idx+1:], ",") {
+            col = strings.TrimSpace(col)
+            if col != "" {
+                c.SkipColumns[ref] = append(c.SkipColumns[ref], col)
+            }
+        }
+    }
+    return nil
+}
+
+// syncOptions groups the knobs that apply uniformly across every table
+// in a run (as opposed to syncConfig, which varies per table).
+type syncOptions struct {
+    Verbose           bool
+    Bulk              bool
+    BulkThreshold     int
+    ReadOnlySource    bool
+    PKColumnsOverride []string
+}
+
+// ForceFor reports whether ref should be force-upserted regardless of
+// row hash differences.
+func (c *syncConfig) ForceFor(ref tableRef) bool {
+    return c.ForceTables[ref]
+}
+
+// SkipColumnsFor returns the set of columns to exclude from ref's sync:
+// the tool-wide defaults plus anything configured for that table.
+func (c *syncConfig) SkipColumnsFor(ref tableRef) map[string]bool {
+    skip := make(map[string]bool, len(defaultSkipColumns)+len(c.SkipColumns[ref]))
+    for _, col := range defaultSkipColumns {
+        skip[col] = true
+    }
+    for _, col := range c.SkipColumns[ref] {
+        skip[col] = true
+    }
+    return skip
+}
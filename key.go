@@ -0,0 +1,57 @@
+package main
+
+import (
+    "fmt"
+    "strings"
+)
+
+// keyCodec turns a row's primary key values into a stable map key.
+// Unlike the old "::"-joined string, it length-prefixes each value so a
+// PK value containing the delimiter can't collide with a neighboring
+// column, and it never re-derives typed values from the key itself —
+// callers keep the original pgtype-decoded values (see rowKeys) and use
+// the encoded key purely for equality/lookup.
+type keyCodec struct {
+    pkCols []string
+}
+
+func newKeyCodec(pkCols []string) *keyCodec {
+    return &keyCodec{pkCols: append([]string(nil), pkCols...)}
+}
+
+// encode renders values (in pkCols order) as a single comparable string
+// safe to use as a Go map key.
+func (c *keyCodec) encode(values []interface{}) string {
+    var b strings.Builder
+    for _, v := range values {
+        s := fmt.Sprintf("%v", v)
+        fmt.Fprintf(&b, "%d:%s", len(s), s)
+    }
+    return b.String()
+}
+
+// rowKeys accumulates the encoded-key -> original-typed-values mapping
+// for one side (source or target) of a table diff, so the typed PK
+// values scanned off the wire can be reused later for parameter binding
+// instead of being reconstructed from a string.
+type rowKeys struct {
+    codec  *keyCodec
+    values map[string][]interface{}
+}
+
+func newRowKeys(codec *keyCodec) *rowKeys {
+    return &rowKeys{codec: codec, values: make(map[string][]interface{})}
+}
+
+// add encodes pkValues and remembers the typed values under that key,
+// returning the key for the caller to use as a hash-map index.
+func (rk *rowKeys) add(pkValues []interface{}) string {
+    key := rk.codec.encode(pkValues)
+    rk.values[key] = pkValues
+    return key
+}
+
+// Get returns the original typed PK values for a previously-added key.
+func (rk *rowKeys) Get(key string) []interface{} {
+    return rk.values[key]
+}
@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+func TestKeyCodecEncodeAvoidsDelimiterCollisions(t *testing.T) {
+    codec := newKeyCodec([]string{"a", "b"})
+
+    k1 := codec.encode([]interface{}{"a::b", "c"})
+    k2 := codec.encode([]interface{}{"a", "b::c"})
+
+    if k1 == k2 {
+        t.Fatalf("encode(%q) and encode(%q) collided on %q; the old \"::\"-joined key would have matched here", []interface{}{"a::b", "c"}, []interface{}{"a", "b::c"}, k1)
+    }
+}
+
+func TestKeyCodecEncodeStableForEqualInputs(t *testing.T) {
+    codec := newKeyCodec([]string{"id"})
+
+    a := codec.encode([]interface{}{42})
+    b := codec.encode([]interface{}{42})
+    if a != b {
+        t.Errorf("encode should be stable for equal inputs, got %q and %q", a, b)
+    }
+}
+
+func TestRowKeysGetReturnsOriginalTypedValues(t *testing.T) {
+    codec := newKeyCodec([]string{"id"})
+    rk := newRowKeys(codec)
+
+    want := []interface{}{42}
+    key := rk.add(want)
+
+    got := rk.Get(key)
+    if len(got) != len(want) || got[0] != want[0] {
+        t.Errorf("Get(%q) = %v, want %v", key, got, want)
+    }
+}
@@ -19,7 +19,18 @@ func main() {
     version := pflag.Bool("version", false, "Print version and exit")
     verbose := pflag.Bool("verbose", false, "Enable verbose output")
     dryRun := pflag.Bool("dry-run", false, "Simulate actions without making changes")
-    force := pflag.Bool("force", false, "Force upsert of all rows from source into target, ignoring differences")
+    force := pflag.Bool("force", false, "Force upsert of all rows from source into target, ignoring differences (single --table only; use --force-table for --tables)")
+    forceTables := pflag.StringArray("force-table", nil, "schema.table to force-upsert regardless of row hash, within a --tables run (repeatable)")
+    skipColumns := pflag.StringArray("skip-columns", nil, "schema.table:col1,col2 columns to exclude from sync, in addition to the tool defaults (repeatable)")
+    configFile := pflag.String("config", "", "YAML config file with force/skip_columns entries, merged with --force-table/--skip-columns")
+    tables := pflag.StringArray("tables", nil, "schema.table[:where] entries to sync (repeatable); enables multi-table subset mode")
+    followFKs := pflag.Bool("follow-fks", false, "When syncing --tables, pull in the transitive closure of referenced parent rows via foreign keys")
+    bulk := pflag.Bool("bulk", false, "Always use the COPY-based bulk transfer path instead of per-row upserts")
+    bulkThreshold := pflag.Int("bulk-threshold", 1000, "Switch to the COPY-based bulk transfer path once a table's changed row count reaches this size")
+    readOnlySource := pflag.Bool("read-only-source", false, "Derive schema info from pg_catalog instead of information_schema, for replicas/managed instances the caller doesn't own tables on")
+    pkColumns := pflag.StringSlice("pk-columns", nil, "Explicit primary key column list, used in place of primary key discovery for every table synced")
+    parallel := pflag.Int("parallel", 1, "Number of tables to sync concurrently within a --tables run, respecting FK order when --follow-fks is set")
+    singleTransaction := pflag.Bool("single-transaction", false, "Force a --tables run back to one sequential cross-table transaction even when --parallel > 1")
     help := pflag.Bool("help", false, "Display usage information")
 
     pflag.Parse()
@@ -42,8 +53,8 @@ func main() {
         return
     }
 
-    if *source == "" || *target == "" || *table == "" {
-        fmt.Println("Error: --source, --target, and --table are required.")
+    if *source == "" || *target == "" || (*table == "" && len(*tables) == 0) {
+        fmt.Println("Error: --source, --target, and either --table or --tables are required.")
         pflag.Usage()
         os.Exit(1)
     }
@@ -63,10 +74,47 @@ func main() {
     }
     defer targetConn.Close(ctx)
 
-    err = makeTableSame(ctx, sourceConn, targetConn, *table, *where, *verbose, *dryRun, *force, logger)
+    cfg, err := newSyncConfig(*forceTables, *skipColumns)
     if err != nil {
         logger.Fatal(err)
     }
+    if *configFile != "" {
+        if err := loadConfigFile(*configFile, cfg); err != nil {
+            logger.Fatal(err)
+        }
+    }
+
+    opts := syncOptions{
+        Verbose:           *verbose,
+        Bulk:              *bulk,
+        BulkThreshold:     *bulkThreshold,
+        ReadOnlySource:    *readOnlySource,
+        PKColumnsOverride: *pkColumns,
+    }
+
+    if len(*tables) > 0 {
+        specs, err := parseTableSpecs(*tables)
+        if err != nil {
+            logger.Fatal(err)
+        }
+        if *parallel > 1 && !*singleTransaction {
+            err = SyncSubsetParallel(ctx, *source, *target, specs, *followFKs, *dryRun, cfg, opts, *parallel, logger)
+        } else {
+            err = SyncSubset(ctx, sourceConn, targetConn, specs, *followFKs, *dryRun, cfg, opts, logger)
+        }
+        if err != nil {
+            logger.Fatal(err)
+        }
+    } else {
+        if *force {
+            schema, tableName := splitSchemaTable(*table)
+            cfg.ForceTables[tableRef{schema: schema, table: tableName}] = true
+        }
+        err = makeTableSame(ctx, sourceConn, targetConn, *table, *where, *dryRun, cfg, opts, logger)
+        if err != nil {
+            logger.Fatal(err)
+        }
+    }
 
     if *dryRun {
         logger.Println("Dry-run completed! No changes made to the target. 🐷")
@@ -75,27 +123,85 @@ func main() {
     }
 }
 
-func makeTableSame(ctx context.Context, source, target *pgx.Conn, table, where string, verbose, dryRun, force bool, logger *log.Logger) error {
+func makeTableSame(ctx context.Context, source, target *pgx.Conn, table, where string, dryRun bool, cfg *syncConfig, opts syncOptions, logger *log.Logger) error {
     schema, tableName := splitSchemaTable(table)
+    ref := tableRef{schema: schema, table: tableName}
 
-    pkCols, err := getPrimaryKeyColumns(ctx, source, schema, tableName)
+    tx, err := target.Begin(ctx)
+    if err != nil {
+        return fmt.Errorf("error beginning transaction on target: %w", err)
+    }
+    committed := false
+    defer func() {
+        if !committed {
+            tx.Rollback(ctx)
+        }
+    }()
+
+    if _, err := tx.Exec(ctx, "SET CONSTRAINTS ALL DEFERRED"); err != nil {
+        return fmt.Errorf("error deferring constraints on target: %w", err)
+    }
+
+    if err := syncTableInTx(ctx, source, target, tx, schema, tableName, where, nil, cfg.ForceFor(ref), cfg.SkipColumnsFor(ref), opts, logger); err != nil {
+        return err
+    }
+
+    if dryRun {
+        if err := tx.Rollback(ctx); err != nil {
+            return fmt.Errorf("error rolling back transaction: %w", err)
+        }
+        committed = true
+        if opts.Verbose {
+            logger.Println("Dry-run mode: transaction rolled back.")
+        }
+        return nil
+    }
+
+    if err := tx.Commit(ctx); err != nil {
+        return fmt.Errorf("error committing transaction: %w", err)
+    }
+    committed = true
+    if opts.Verbose {
+        logger.Println("Saved changes.")
+    }
+    return nil
+}
+
+// syncTableInTx diffs one table between source and target and applies
+// inserts/upserts/deletes on tx, the target's already-open transaction.
+// It is the unit of work shared by the single-table CLI path and the
+// multi-table subsetter, which drives many calls to this function inside
+// one transaction so FK ordering and SET CONSTRAINTS DEFERRED apply
+// across the whole run. force and skipColumns are resolved per table by
+// the caller from a syncConfig; opts holds the knobs that apply the same
+// way to every table in the run. where may reference $1, $2, ... bound
+// from whereArgs, in the order the subsetter's FK-closure filters use
+// (the single-table CLI path always passes whereArgs as nil since its
+// --where flag never contains placeholders).
+func syncTableInTx(ctx context.Context, source, target *pgx.Conn, tx pgx.Tx, schema, tableName, where string, whereArgs []interface{}, force bool, skipColumns map[string]bool, opts syncOptions, logger *log.Logger) error {
+    verbose := opts.Verbose
+    pkCols, err := resolvePrimaryKeyColumns(ctx, source, schema, tableName, opts.ReadOnlySource, opts.PKColumnsOverride)
     if err != nil {
         return fmt.Errorf("error getting primary key columns: %w", err)
     }
     if len(pkCols) == 0 {
-        return fmt.Errorf("table %s has no primary key", table)
+        return fmt.Errorf("table %s.%s has no primary key (pass --pk-columns to override)", schema, tableName)
     }
 
     if verbose {
         logger.Printf("Primary key columns: %v", pkCols)
     }
 
-    colNames, err := getColumnNames(ctx, source, schema, tableName)
+    var colNames []string
+    if opts.ReadOnlySource {
+        colNames, err = getColumnNamesCatalog(ctx, source, schema, tableName)
+    } else {
+        colNames, err = getColumnNames(ctx, source, schema, tableName)
+    }
     if err != nil {
         return fmt.Errorf("error getting column names: %w", err)
     }
 
-    skipColumns := map[string]bool{"search_vector": true}
     filteredColNames := make([]string, 0, len(colNames))
     for _, col := range colNames {
         if !skipColumns[col] {
@@ -119,15 +225,17 @@ func makeTableSame(ctx context.Context, source, target *pgx.Conn, table, where s
     }
 
     if verbose {
-        logger.Printf("Source query: %s", sourceQuery)
+        logger.Printf("Source query: %s (args: %v)", sourceQuery, whereArgs)
     }
 
-    sourceRows, err := source.Query(ctx, sourceQuery)
+    sourceRows, err := source.Query(ctx, sourceQuery, whereArgs...)
     if err != nil {
         return fmt.Errorf("error querying source: %w", err)
     }
     defer sourceRows.Close()
 
+    codec := newKeyCodec(pkCols)
+    sourceKeys := newRowKeys(codec)
     sourceData := make(map[string]string)
     for sourceRows.Next() {
         pkValues := make([]interface{}, len(pkCols))
@@ -142,7 +250,7 @@ func makeTableSame(ctx context.Context, source, target *pgx.Conn, table, where s
             return fmt.Errorf("error scanning source row: %w", err)
         }
 
-        key := makeKey(pkValues)
+        key := sourceKeys.add(pkValues)
         sourceData[key] = rowHash
     }
     if sourceRows.Err() != nil {
@@ -165,77 +273,77 @@ func makeTableSame(ctx context.Context, source, target *pgx.Conn, table, where s
         return nil
     }
 
-    pkPlaceholders := make([]string, len(pkCols))
-    for i := range pkCols {
-        pkPlaceholders[i] = fmt.Sprintf("%s = ANY($1)", quoteIdentifier(pkCols[i]))
-    }
-    targetWhereClause := strings.Join(pkPlaceholders, " AND ")
-
-    targetQuery := fmt.Sprintf(
-        "SELECT %s, md5(row_to_json(t)::text) AS row_hash FROM %s.%s t WHERE %s",
-        pkColsStr, quoteIdentifier(schema), quoteIdentifier(tableName), targetWhereClause,
-    )
-
-    if verbose {
-        logger.Printf("Target query: %s", targetQuery)
+    // Join against a VALUES list of the typed PK tuples rather than
+    // binding a single-column ANY($1) array: that form silently ignored
+    // every PK column past the first for composite keys.
+    keyAliasCols := make([]string, len(pkCols))
+    joinConditions := make([]string, len(pkCols))
+    for i, col := range pkCols {
+        keyAliasCols[i] = fmt.Sprintf("k%d", i+1)
+        joinConditions[i] = fmt.Sprintf("t.%s = k.%s", quoteIdentifier(col), keyAliasCols[i])
     }
 
-    targetPKValues := make([][]interface{}, len(targetKeys))
-    for i, key := range targetKeys {
-        targetPKValues[i] = splitKey(key)
-    }
+    targetRowKeys := newRowKeys(codec)
+    targetData := make(map[string]string)
 
-    var args []interface{}
-    for _, pkValues := range targetPKValues {
-        args = append(args, pkValues[0])
-    }
+    for _, batch := range chunkKeys(targetKeys, len(pkCols)) {
+        args := make([]interface{}, 0, len(batch)*len(pkCols))
+        valueRows := make([]string, len(batch))
+        n := 1
+        for i, key := range batch {
+            pkValues := sourceKeys.Get(key)
+            placeholders := make([]string, len(pkValues))
+            for j, v := range pkValues {
+                placeholders[j] = fmt.Sprintf("$%d", n)
+                args = append(args, v)
+                n++
+            }
+            valueRows[i] = "(" + strings.Join(placeholders, ", ") + ")"
+        }
 
-    targetRows, err := target.Query(ctx, targetQuery, args)
-    if err != nil {
-        return fmt.Errorf("error querying target: %w", err)
-    }
-    defer targetRows.Close()
+        targetQuery := fmt.Sprintf(
+            "SELECT %s, md5(row_to_json(t)::text) AS row_hash FROM %s.%s t JOIN (VALUES %s) AS k(%s) ON %s",
+            pkColsStr, quoteIdentifier(schema), quoteIdentifier(tableName),
+            strings.Join(valueRows, ", "), strings.Join(keyAliasCols, ", "), strings.Join(joinConditions, " AND "),
+        )
 
-    targetData := make(map[string]string)
-    for targetRows.Next() {
-        pkValues := make([]interface{}, len(pkCols))
-        scanArgs := make([]interface{}, len(pkCols)+1)
-        for i := range pkValues {
-            scanArgs[i] = &pkValues[i]
+        if verbose {
+            logger.Printf("Target query: %s", targetQuery)
         }
-        var rowHash string
-        scanArgs[len(pkCols)] = &rowHash
 
-        if err := targetRows.Scan(scanArgs...); err != nil {
-            return fmt.Errorf("error scanning target row: %w", err)
+        targetRows, err := target.Query(ctx, targetQuery, args...)
+        if err != nil {
+            return fmt.Errorf("error querying target: %w", err)
         }
 
-        key := makeKey(pkValues)
-        targetData[key] = rowHash
-    }
-    if targetRows.Err() != nil {
-        return fmt.Errorf("error reading target rows: %w", targetRows.Err())
+        for targetRows.Next() {
+            pkValues := make([]interface{}, len(pkCols))
+            scanArgs := make([]interface{}, len(pkCols)+1)
+            for i := range pkValues {
+                scanArgs[i] = &pkValues[i]
+            }
+            var rowHash string
+            scanArgs[len(pkCols)] = &rowHash
+
+            if err := targetRows.Scan(scanArgs...); err != nil {
+                targetRows.Close()
+                return fmt.Errorf("error scanning target row: %w", err)
+            }
+
+            key := targetRowKeys.add(pkValues)
+            targetData[key] = rowHash
+        }
+        err = targetRows.Err()
+        targetRows.Close()
+        if err != nil {
+            return fmt.Errorf("error reading target rows: %w", err)
+        }
     }
 
     if verbose {
         logger.Printf("Fetched %d rows from target", len(targetData))
     }
 
-    tx, err := target.Begin(ctx)
-    if err != nil {
-        return fmt.Errorf("error beginning transaction on target: %w", err)
-    }
-    defer func() {
-        if err != nil {
-            tx.Rollback(ctx)
-        }
-    }()
-
-    _, err = tx.Exec(ctx, "SET CONSTRAINTS ALL DEFERRED")
-    if err != nil {
-        return fmt.Errorf("error deferring constraints on target: %w", err)
-    }
-
     keysToUpsert := make([]string, 0)
     keysToInsert := make([]string, 0)
     keysToDelete := make([]string, 0)
@@ -285,59 +393,74 @@ func makeTableSame(ctx context.Context, source, target *pgx.Conn, table, where s
         logger.Printf("Upsert query: %s", upsertQuery)
     }
 
-    for _, key := range append(keysToInsert, keysToUpsert...) {
-        pkValues := splitKey(key)
-        selectQuery := fmt.Sprintf(
-            "SELECT %s FROM %s.%s WHERE %s",
-            selectCols, quoteIdentifier(schema), quoteIdentifier(tableName), buildWhereClause(pkCols),
-        )
-        sourceRow := source.QueryRow(ctx, selectQuery, pkValues...)
+    changedKeys := append(keysToInsert, keysToUpsert...)
+    useBulk := opts.Bulk || len(changedKeys) >= opts.BulkThreshold || len(keysToDelete) >= opts.BulkThreshold
 
-        columns := make([]interface{}, len(filteredColNames))
-        columnPointers := make([]interface{}, len(filteredColNames))
-        for i := range columns {
-            columnPointers[i] = &columns[i]
+    if useBulk {
+        if verbose {
+            logger.Println("Using COPY-based bulk transfer path")
         }
-
-        err = sourceRow.Scan(columnPointers...)
-        if err != nil {
-            return fmt.Errorf("error scanning source row data: %w", err)
+        // A failed CREATE TEMP TABLE/CopyFrom/INSERT aborts tx itself, not
+        // just the statement that failed, so without a savepoint the
+        // per-row fallback below (and, inside SyncSubset, every other
+        // table still left to sync on this same tx) would fail with
+        // "current transaction is aborted". Isolate the bulk attempt so a
+        // failure can be undone without losing the whole transaction.
+        if _, spErr := tx.Exec(ctx, "SAVEPOINT bulk_sync"); spErr != nil {
+            return fmt.Errorf("error creating bulk savepoint: %w", spErr)
         }
-
-        _, err = tx.Exec(ctx, upsertQuery, columns...)
-        if err != nil {
-            return fmt.Errorf("error upserting row into target: %w", err)
+        err = bulkUpsert(ctx, source, tx, schema, tableName, filteredColNames, pkCols, changedKeys, sourceKeys, verbose, logger)
+        if err == nil {
+            err = bulkDelete(ctx, tx, schema, tableName, pkCols, keysToDelete, targetRowKeys, verbose, logger)
         }
-    }
-
-    deleteQuery := fmt.Sprintf(
-        "DELETE FROM %s.%s WHERE %s",
-        quoteIdentifier(schema), quoteIdentifier(tableName), buildWhereClause(pkCols),
-    )
-
-    for _, key := range keysToDelete {
-        pkValues := splitKey(key)
-        _, err = tx.Exec(ctx, deleteQuery, pkValues...)
         if err != nil {
-            return fmt.Errorf("error deleting row from target: %w", err)
+            logger.Printf("bulk transfer failed, falling back to per-row sync: %v", err)
+            if _, rbErr := tx.Exec(ctx, "ROLLBACK TO SAVEPOINT bulk_sync"); rbErr != nil {
+                return fmt.Errorf("error rolling back bulk savepoint after bulk failure (%v): %w", err, rbErr)
+            }
+            useBulk = false
+        } else if _, relErr := tx.Exec(ctx, "RELEASE SAVEPOINT bulk_sync"); relErr != nil {
+            return fmt.Errorf("error releasing bulk savepoint: %w", relErr)
         }
     }
 
-    if dryRun {
-        err = tx.Rollback(ctx)
-        if err != nil {
-            return fmt.Errorf("error rolling back transaction: %w", err)
-        }
-        if verbose {
-            logger.Println("Dry-run mode: transaction rolled back.")
-        }
-    } else {
-        err = tx.Commit(ctx)
-        if err != nil {
-            return fmt.Errorf("error committing transaction: %w", err)
+    if !useBulk {
+        for _, key := range changedKeys {
+            pkValues := sourceKeys.Get(key)
+            selectQuery := fmt.Sprintf(
+                "SELECT %s FROM %s.%s WHERE %s",
+                selectCols, quoteIdentifier(schema), quoteIdentifier(tableName), buildWhereClause(pkCols),
+            )
+            sourceRow := source.QueryRow(ctx, selectQuery, pkValues...)
+
+            columns := make([]interface{}, len(filteredColNames))
+            columnPointers := make([]interface{}, len(filteredColNames))
+            for i := range columns {
+                columnPointers[i] = &columns[i]
+            }
+
+            err = sourceRow.Scan(columnPointers...)
+            if err != nil {
+                return fmt.Errorf("error scanning source row data: %w", err)
+            }
+
+            _, err = tx.Exec(ctx, upsertQuery, columns...)
+            if err != nil {
+                return fmt.Errorf("error upserting row into target: %w", err)
+            }
         }
-        if verbose {
-            logger.Println("Saved changes.")
+
+        deleteQuery := fmt.Sprintf(
+            "DELETE FROM %s.%s WHERE %s",
+            quoteIdentifier(schema), quoteIdentifier(tableName), buildWhereClause(pkCols),
+        )
+
+        for _, key := range keysToDelete {
+            pkValues := targetRowKeys.Get(key)
+            _, err = tx.Exec(ctx, deleteQuery, pkValues...)
+            if err != nil {
+                return fmt.Errorf("error deleting row from target: %w", err)
+            }
         }
     }
 
@@ -402,6 +525,33 @@ func getColumnNames(ctx context.Context, conn *pgx.Conn, schema, table string) (
     return colNames, nil
 }
 
+// maxBindParams keeps queries well under PostgreSQL's 65535-parameter
+// limit for the extended protocol, which a naive VALUES-join over an
+// unbounded key list can otherwise exceed.
+const maxBindParams = 60000
+
+// chunkKeys splits keys into batches sized so a VALUES-join binding
+// colsPerKey params per row stays under maxBindParams per query.
+func chunkKeys(keys []string, colsPerKey int) [][]string {
+    if colsPerKey < 1 {
+        colsPerKey = 1
+    }
+    batchSize := maxBindParams / colsPerKey
+    if batchSize < 1 {
+        batchSize = 1
+    }
+    var batches [][]string
+    for len(keys) > 0 {
+        n := batchSize
+        if n > len(keys) {
+            n = len(keys)
+        }
+        batches = append(batches, keys[:n])
+        keys = keys[n:]
+    }
+    return batches
+}
+
 func buildWhereClause(pkCols []string) string {
     conditions := make([]string, len(pkCols))
     for i, col := range pkCols {
@@ -410,23 +560,6 @@ func buildWhereClause(pkCols []string) string {
     return strings.Join(conditions, " AND ")
 }
 
-func makeKey(values []interface{}) string {
-    parts := make([]string, len(values))
-    for i, v := range values {
-        parts[i] = fmt.Sprintf("%v", v)
-    }
-    return strings.Join(parts, "::")
-}
-
-func splitKey(key string) []interface{} {
-    parts := strings.Split(key, "::")
-    values := make([]interface{}, len(parts))
-    for i, part := range parts {
-        values[i] = part
-    }
-    return values
-}
-
 func joinIdentifiers(identifiers []string) string {
     for i, id := range identifiers {
         identifiers[i] = quoteIdentifier(id)
@@ -0,0 +1,224 @@
+package main
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "sync"
+
+    "github.com/jackc/pgx/v4"
+    "github.com/jackc/pgx/v4/pgxpool"
+)
+
+// SyncSubsetParallel runs the same FK-ordered multi-table sync as
+// SyncSubset, but fans independent tables out across a worker pool
+// instead of serializing every table inside one cross-table transaction.
+// Tables in the same level of the FK dependency DAG (no unresolved
+// parent in the set) may run concurrently; a table waits on all of its
+// own parents via a per-table sync.WaitGroup rather than a coarser
+// level-by-level barrier, so a table whose parents finish early doesn't
+// sit idle behind slower siblings.
+//
+// Trade-off: a single cross-table transaction is no longer possible once
+// workers hold independent connections, so each table commits on its own
+// — a partial failure can leave some tables synced and others not. Pass
+// --single-transaction (routing to SyncSubset instead) when that
+// all-or-nothing guarantee matters more than throughput.
+func SyncSubsetParallel(ctx context.Context, sourceConnStr, targetConnStr string, specs []tableSpec, followFKs, dryRun bool, cfg *syncConfig, opts syncOptions, parallel int, logger *log.Logger) error {
+    meta, err := pgxpool.Connect(ctx, sourceConnStr)
+    if err != nil {
+        return fmt.Errorf("error connecting to source for metadata: %w", err)
+    }
+    defer meta.Close()
+
+    var fks []fkConstraint
+    parents := make(map[tableRef][]tableRef, len(specs))
+    for _, s := range specs {
+        parents[s.ref()] = nil
+    }
+    if followFKs {
+        metaConn, err := meta.Acquire(ctx)
+        if err != nil {
+            return fmt.Errorf("error acquiring metadata connection: %w", err)
+        }
+        var allFKs []fkConstraint
+        if opts.ReadOnlySource {
+            allFKs, err = getForeignKeysCatalog(ctx, metaConn.Conn())
+        } else {
+            allFKs, err = getForeignKeys(ctx, metaConn.Conn())
+        }
+        metaConn.Release()
+        if err != nil {
+            return err
+        }
+        parents, fks = buildDependencyGraph(specs, allFKs)
+    }
+
+    if hasCycle(specs, parents) {
+        logger.Println("warning: cyclic foreign keys detected among --tables tables; --parallel can't safely order these, falling back to sequential sync")
+        sourceConn, err := pgx.Connect(ctx, sourceConnStr)
+        if err != nil {
+            return fmt.Errorf("error connecting to source: %w", err)
+        }
+        defer sourceConn.Close(ctx)
+        targetConn, err := pgx.Connect(ctx, targetConnStr)
+        if err != nil {
+            return fmt.Errorf("error connecting to target: %w", err)
+        }
+        defer targetConn.Close(ctx)
+        return SyncSubset(ctx, sourceConn, targetConn, specs, followFKs, dryRun, cfg, opts, logger)
+    }
+
+    var whereByTable map[tableRef]whereClause
+    if followFKs {
+        order := topologicalOrder(specs, parents, logger)
+        metaConn, err := meta.Acquire(ctx)
+        if err != nil {
+            return fmt.Errorf("error acquiring metadata connection: %w", err)
+        }
+        whereByTable, err = closureWheres(ctx, metaConn.Conn(), specs, fks, order, opts.Verbose, logger)
+        metaConn.Release()
+        if err != nil {
+            return err
+        }
+    } else {
+        whereByTable = make(map[tableRef]whereClause, len(specs))
+        for _, s := range specs {
+            whereByTable[s.ref()] = whereClause{SQL: s.where}
+        }
+    }
+
+    sourcePool, err := pgxpool.Connect(ctx, fmt.Sprintf("%s&pool_max_conns=%d", sourceConnStr, parallel))
+    if err != nil {
+        sourcePool, err = pgxpool.Connect(ctx, sourceConnStr)
+        if err != nil {
+            return fmt.Errorf("error connecting source pool: %w", err)
+        }
+    }
+    defer sourcePool.Close()
+
+    targetPool, err := pgxpool.Connect(ctx, fmt.Sprintf("%s&pool_max_conns=%d", targetConnStr, parallel))
+    if err != nil {
+        targetPool, err = pgxpool.Connect(ctx, targetConnStr)
+        if err != nil {
+            return fmt.Errorf("error connecting target pool: %w", err)
+        }
+    }
+    defer targetPool.Close()
+
+    logCh := make(chan string, 256)
+    var logWG sync.WaitGroup
+    logWG.Add(1)
+    go func() {
+        defer logWG.Done()
+        for msg := range logCh {
+            fmt.Fprint(logger.Writer(), msg)
+        }
+    }()
+    serialLogger := log.New(&channelWriter{ch: logCh}, "", log.LstdFlags)
+
+    waitGroups := make(map[tableRef]*sync.WaitGroup, len(specs))
+    for _, s := range specs {
+        waitGroups[s.ref()] = &sync.WaitGroup{}
+        waitGroups[s.ref()].Add(1)
+    }
+
+    sem := make(chan struct{}, parallel)
+    errCh := make(chan error, len(specs))
+    var wg sync.WaitGroup
+
+    for _, s := range specs {
+        s := s
+        ref := s.ref()
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            defer waitGroups[ref].Done()
+
+            for _, p := range parents[ref] {
+                waitGroups[p].Wait()
+            }
+
+            sem <- struct{}{}
+            defer func() { <-sem }()
+
+            if err := syncOneTableParallel(ctx, sourcePool, targetPool, ref, whereByTable[ref], dryRun, cfg, opts, serialLogger); err != nil {
+                errCh <- fmt.Errorf("error syncing %s: %w", ref, err)
+            }
+        }()
+    }
+
+    wg.Wait()
+    close(logCh)
+    logWG.Wait()
+    close(errCh)
+
+    for err := range errCh {
+        if err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// syncOneTableParallel runs one table's sync in its own transaction on a
+// connection checked out from targetPool/sourcePool, since workers can no
+// longer share the single cross-table transaction SyncSubset uses.
+func syncOneTableParallel(ctx context.Context, sourcePool, targetPool *pgxpool.Pool, ref tableRef, where whereClause, dryRun bool, cfg *syncConfig, opts syncOptions, logger *log.Logger) error {
+    sourceConn, err := sourcePool.Acquire(ctx)
+    if err != nil {
+        return fmt.Errorf("error acquiring source connection: %w", err)
+    }
+    defer sourceConn.Release()
+
+    targetConn, err := targetPool.Acquire(ctx)
+    if err != nil {
+        return fmt.Errorf("error acquiring target connection: %w", err)
+    }
+    defer targetConn.Release()
+
+    tx, err := targetConn.Begin(ctx)
+    if err != nil {
+        return fmt.Errorf("error beginning transaction on target: %w", err)
+    }
+    committed := false
+    defer func() {
+        if !committed {
+            tx.Rollback(ctx)
+        }
+    }()
+
+    if _, err := tx.Exec(ctx, "SET CONSTRAINTS ALL DEFERRED"); err != nil {
+        return fmt.Errorf("error deferring constraints on target: %w", err)
+    }
+
+    if err := syncTableInTx(ctx, sourceConn.Conn(), targetConn.Conn(), tx, ref.schema, ref.table, where.SQL, where.Args, cfg.ForceFor(ref), cfg.SkipColumnsFor(ref), opts, logger); err != nil {
+        return err
+    }
+
+    if dryRun {
+        if err := tx.Rollback(ctx); err != nil {
+            return fmt.Errorf("error rolling back transaction: %w", err)
+        }
+        committed = true
+        return nil
+    }
+
+    if err := tx.Commit(ctx); err != nil {
+        return fmt.Errorf("error committing transaction: %w", err)
+    }
+    committed = true
+    return nil
+}
+
+// channelWriter adapts a string channel to io.Writer so *log.Logger can
+// write into it, serializing concurrent workers' verbose/progress output
+// through the single goroutine draining the channel.
+type channelWriter struct {
+    ch chan<- string
+}
+
+func (w *channelWriter) Write(p []byte) (int, error) {
+    w.ch <- string(p)
+    return len(p), nil
+}
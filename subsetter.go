@@ -0,0 +1,474 @@
+package main
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "strings"
+
+    "github.com/jackc/pgx/v4"
+)
+
+// tableRef identifies a table by schema and name, used as a map key
+// throughout the subsetter.
+type tableRef struct {
+    schema string
+    table  string
+}
+
+func (t tableRef) String() string {
+    return t.schema + "." + t.table
+}
+
+// tableSpec is one entry parsed from --tables: a table to sync plus an
+// optional row filter.
+type tableSpec struct {
+    schema string
+    table  string
+    where  string
+}
+
+func (t tableSpec) ref() tableRef {
+    return tableRef{schema: t.schema, table: t.table}
+}
+
+// parseTableSpecs turns repeated --tables=schema.table[:where] entries
+// (each possibly comma-separated) into tableSpecs.
+func parseTableSpecs(raw []string) ([]tableSpec, error) {
+    var specs []tableSpec
+    for _, group := range raw {
+        for _, entry := range strings.Split(group, ",") {
+            entry = strings.TrimSpace(entry)
+            if entry == "" {
+                continue
+            }
+            tablePart := entry
+            where := ""
+            if idx := strings.Index(entry, ":"); idx != -1 {
+                tablePart = entry[:idx]
+                where = entry[idx+1:]
+            }
+            schema, table := splitSchemaTable(tablePart)
+            specs = append(specs, tableSpec{schema: schema, table: table, where: where})
+        }
+    }
+    if len(specs) == 0 {
+        return nil, fmt.Errorf("--tables did not yield any schema.table entries")
+    }
+    return specs, nil
+}
+
+// fkConstraint describes one foreign key from a child table's columns to
+// a parent table's columns, in FK declaration order.
+type fkConstraint struct {
+    childSchema  string
+    childTable   string
+    childCols    []string
+    parentSchema string
+    parentTable  string
+    parentCols   []string
+}
+
+// getForeignKeys reads every FK constraint in the database from
+// information_schema.referential_constraints/key_column_usage, so the
+// subsetter can build a dependency graph without being told about them.
+func getForeignKeys(ctx context.Context, conn *pgx.Conn) ([]fkConstraint, error) {
+    query := `
+        SELECT
+            rc.constraint_name,
+            kcu.table_schema, kcu.table_name, kcu.column_name, kcu.ordinal_position,
+            ccu.table_schema, ccu.table_name, ccu.column_name
+        FROM information_schema.referential_constraints rc
+        JOIN information_schema.key_column_usage kcu
+          ON kcu.constraint_name = rc.constraint_name
+          AND kcu.constraint_schema = rc.constraint_schema
+        JOIN information_schema.key_column_usage ccu
+          ON ccu.constraint_name = rc.unique_constraint_name
+          AND ccu.constraint_schema = rc.unique_constraint_schema
+          AND ccu.ordinal_position = kcu.ordinal_position
+        ORDER BY rc.constraint_name, kcu.ordinal_position
+    `
+    rows, err := conn.Query(ctx, query)
+    if err != nil {
+        return nil, fmt.Errorf("error reading foreign keys: %w", err)
+    }
+    defer rows.Close()
+
+    byName := make(map[string]*fkConstraint)
+    var order []string
+    for rows.Next() {
+        var name, childSchema, childTable, childCol, parentSchema, parentTable, parentCol string
+        var ordinal int
+        if err := rows.Scan(&name, &childSchema, &childTable, &childCol, &ordinal, &parentSchema, &parentTable, &parentCol); err != nil {
+            return nil, fmt.Errorf("error scanning foreign key row: %w", err)
+        }
+        fk, ok := byName[name]
+        if !ok {
+            fk = &fkConstraint{childSchema: childSchema, childTable: childTable, parentSchema: parentSchema, parentTable: parentTable}
+            byName[name] = fk
+            order = append(order, name)
+        }
+        fk.childCols = append(fk.childCols, childCol)
+        fk.parentCols = append(fk.parentCols, parentCol)
+    }
+    if rows.Err() != nil {
+        return nil, fmt.Errorf("error reading foreign key rows: %w", rows.Err())
+    }
+
+    fks := make([]fkConstraint, 0, len(order))
+    for _, name := range order {
+        fks = append(fks, *byName[name])
+    }
+    return fks, nil
+}
+
+// buildDependencyGraph restricts the full FK list to edges that connect
+// two tables present in specs, and returns each table's direct parents.
+func buildDependencyGraph(specs []tableSpec, fks []fkConstraint) (map[tableRef][]tableRef, []fkConstraint) {
+    present := make(map[tableRef]bool, len(specs))
+    for _, s := range specs {
+        present[s.ref()] = true
+    }
+
+    parents := make(map[tableRef][]tableRef, len(specs))
+    for _, s := range specs {
+        parents[s.ref()] = nil
+    }
+
+    var relevant []fkConstraint
+    for _, fk := range fks {
+        child := tableRef{schema: fk.childSchema, table: fk.childTable}
+        parent := tableRef{schema: fk.parentSchema, table: fk.parentTable}
+        if !present[child] || !present[parent] || child == parent {
+            continue
+        }
+        parents[child] = append(parents[child], parent)
+        relevant = append(relevant, fk)
+    }
+    return parents, relevant
+}
+
+// topologicalOrder returns tables ordered so that every parent precedes
+// its children (Kahn's algorithm). Cycles are tolerated: once no more
+// tables can be resolved purely by dependency count, the remaining
+// tables are appended in their original order and a warning is logged.
+// This is safe because the sync runs with SET CONSTRAINTS ALL DEFERRED.
+func topologicalOrder(specs []tableSpec, parents map[tableRef][]tableRef, logger *log.Logger) []tableRef {
+    remaining := make(map[tableRef]bool, len(specs))
+    var original []tableRef
+    for _, s := range specs {
+        remaining[s.ref()] = true
+        original = append(original, s.ref())
+    }
+
+    var ordered []tableRef
+    for len(remaining) > 0 {
+        progressed := false
+        for _, ref := range original {
+            if !remaining[ref] {
+                continue
+            }
+            ready := true
+            for _, p := range parents[ref] {
+                if remaining[p] {
+                    ready = false
+                    break
+                }
+            }
+            if ready {
+                ordered = append(ordered, ref)
+                delete(remaining, ref)
+                progressed = true
+            }
+        }
+        if !progressed {
+            if logger != nil {
+                var stuck []string
+                for _, ref := range original {
+                    if remaining[ref] {
+                        stuck = append(stuck, ref.String())
+                    }
+                }
+                logger.Printf("warning: cyclic foreign keys detected among %v; syncing in declared order", stuck)
+            }
+            for _, ref := range original {
+                if remaining[ref] {
+                    ordered = append(ordered, ref)
+                    delete(remaining, ref)
+                }
+            }
+        }
+    }
+    return ordered
+}
+
+// hasCycle reports whether parents contains a dependency cycle among
+// specs. SyncSubset tolerates cycles because its single transaction
+// defers all constraints, but SyncSubsetParallel waits on a per-table
+// sync.WaitGroup per parent, which would deadlock on a cycle — callers
+// use this to fall back to the sequential path instead.
+func hasCycle(specs []tableSpec, parents map[tableRef][]tableRef) bool {
+    remaining := make(map[tableRef]bool, len(specs))
+    var original []tableRef
+    for _, s := range specs {
+        remaining[s.ref()] = true
+        original = append(original, s.ref())
+    }
+
+    for len(remaining) > 0 {
+        progressed := false
+        for _, ref := range original {
+            if !remaining[ref] {
+                continue
+            }
+            ready := true
+            for _, p := range parents[ref] {
+                if remaining[p] {
+                    ready = false
+                    break
+                }
+            }
+            if ready {
+                delete(remaining, ref)
+                progressed = true
+            }
+        }
+        if !progressed {
+            return true
+        }
+    }
+    return false
+}
+
+// SyncSubset runs a multi-table subset sync inside one target
+// transaction. When followFKs is set, tables are synced parent-first and
+// rows pulled in by a spec's WHERE clause bring along the transitive
+// closure of rows they reference in parent tables; without it, tables are
+// synced in the order given. Each table's inserts, upserts and deletes are
+// applied together on its turn, so deletes also run in that order; they
+// are not reordered child-first.
+func SyncSubset(ctx context.Context, source, target *pgx.Conn, specs []tableSpec, followFKs, dryRun bool, cfg *syncConfig, opts syncOptions, logger *log.Logger) error {
+    verbose := opts.Verbose
+    var fks []fkConstraint
+    var parents map[tableRef][]tableRef
+    if followFKs {
+        var allFKs []fkConstraint
+        var err error
+        if opts.ReadOnlySource {
+            allFKs, err = getForeignKeysCatalog(ctx, source)
+        } else {
+            allFKs, err = getForeignKeys(ctx, source)
+        }
+        if err != nil {
+            return err
+        }
+        parents, fks = buildDependencyGraph(specs, allFKs)
+    } else {
+        parents = make(map[tableRef][]tableRef, len(specs))
+        for _, s := range specs {
+            parents[s.ref()] = nil
+        }
+    }
+
+    order := topologicalOrder(specs, parents, logger)
+    if verbose {
+        var names []string
+        for _, ref := range order {
+            names = append(names, ref.String())
+        }
+        logger.Printf("Sync order (parents first): %v", names)
+    }
+
+    var whereByTable map[tableRef]whereClause
+    if followFKs {
+        var err error
+        whereByTable, err = closureWheres(ctx, source, specs, fks, order, verbose, logger)
+        if err != nil {
+            return err
+        }
+    } else {
+        whereByTable = make(map[tableRef]whereClause, len(specs))
+        for _, s := range specs {
+            whereByTable[s.ref()] = whereClause{SQL: s.where}
+        }
+    }
+
+    tx, err := target.Begin(ctx)
+    if err != nil {
+        return fmt.Errorf("error beginning transaction on target: %w", err)
+    }
+    committed := false
+    defer func() {
+        if !committed {
+            tx.Rollback(ctx)
+        }
+    }()
+
+    if _, err := tx.Exec(ctx, "SET CONSTRAINTS ALL DEFERRED"); err != nil {
+        return fmt.Errorf("error deferring constraints on target: %w", err)
+    }
+
+    for _, ref := range order {
+        where := whereByTable[ref]
+        if verbose {
+            logger.Printf("Syncing %s (where: %q, args: %v)", ref, where.SQL, where.Args)
+        }
+        if err := syncTableInTx(ctx, source, target, tx, ref.schema, ref.table, where.SQL, where.Args, cfg.ForceFor(ref), cfg.SkipColumnsFor(ref), opts, logger); err != nil {
+            return fmt.Errorf("error syncing %s: %w", ref, err)
+        }
+    }
+
+    if dryRun {
+        if err := tx.Rollback(ctx); err != nil {
+            return fmt.Errorf("error rolling back transaction: %w", err)
+        }
+        committed = true
+        if verbose {
+            logger.Println("Dry-run mode: transaction rolled back.")
+        }
+        return nil
+    }
+
+    if err := tx.Commit(ctx); err != nil {
+        return fmt.Errorf("error committing transaction: %w", err)
+    }
+    committed = true
+    if verbose {
+        logger.Println("Saved changes.")
+    }
+    return nil
+}
+
+// whereClause pairs a WHERE-clause fragment (referencing $1, $2, ... in
+// the order the caller must bind them) with the argument values it
+// refers to, so filters built from data read off the wire never need to
+// be string-interpolated back into SQL.
+type whereClause struct {
+    SQL  string
+    Args []interface{}
+}
+
+// closureWheres computes each spec's full effective filter: its own
+// --tables WHERE (if any) ORed with the transitive closure of parent
+// rows referenced anywhere along the FK chain below it, not just its
+// direct children. order must list every spec parent-first (as
+// topologicalOrder returns); closureWheres walks it in reverse so that
+// by the time a table is used to derive its own parents' filters, every
+// one of its children has already folded its contribution in — a table
+// two or more FK hops from the nearest --tables WHERE still gets
+// filtered instead of being synced unfiltered (or left to fail the
+// deferred FK check at COMMIT).
+func closureWheres(ctx context.Context, source *pgx.Conn, specs []tableSpec, fks []fkConstraint, order []tableRef, verbose bool, logger *log.Logger) (map[tableRef]whereClause, error) {
+    effective := make(map[tableRef]whereClause, len(specs))
+    for _, s := range specs {
+        effective[s.ref()] = whereClause{SQL: s.where}
+    }
+
+    contributions := make(map[tableRef][]fkValues)
+    for i := len(order) - 1; i >= 0; i-- {
+        child := order[i]
+
+        if own := contributions[child]; len(own) > 0 {
+            derived := buildInClause(own)
+            if existing := effective[child]; existing.SQL != "" {
+                effective[child] = whereClause{SQL: "(" + existing.SQL + ") OR (" + derived.SQL + ")", Args: derived.Args}
+            } else {
+                effective[child] = derived
+            }
+        }
+
+        childWhere := effective[child]
+        if childWhere.SQL == "" {
+            continue
+        }
+
+        for _, fk := range fks {
+            if fk.childSchema != child.schema || fk.childTable != child.table {
+                continue
+            }
+            childCols := joinIdentifiers(append([]string(nil), fk.childCols...))
+            query := fmt.Sprintf(
+                "SELECT DISTINCT %s FROM %s.%s WHERE %s",
+                childCols, quoteIdentifier(child.schema), quoteIdentifier(child.table), childWhere.SQL,
+            )
+            if verbose {
+                logger.Printf("Collecting referenced parent keys: %s", query)
+            }
+            rows, err := source.Query(ctx, query, childWhere.Args...)
+            if err != nil {
+                return nil, fmt.Errorf("error collecting FK values from %s.%s: %w", child.schema, child.table, err)
+            }
+            values, err := scanColumnValues(rows, len(fk.childCols))
+            if err != nil {
+                return nil, err
+            }
+            if len(values) == 0 {
+                continue
+            }
+            parentRef := tableRef{schema: fk.parentSchema, table: fk.parentTable}
+            contributions[parentRef] = append(contributions[parentRef], fkValues{cols: fk.parentCols, rows: values})
+        }
+    }
+
+    return effective, nil
+}
+
+// fkValues is one FK's contribution to closureWheres: the parent columns
+// it constrains and the distinct child-side values found for them.
+type fkValues struct {
+    cols []string
+    rows [][]interface{}
+}
+
+// buildInClause turns one or more fkValues contributions into a single
+// parameterized WHERE fragment, OR-ing together an "IN (...)" per
+// single-column FK and a "(col1, col2) IN (...)" per composite FK, with
+// every value bound as a $N arg instead of interpolated into the SQL.
+func buildInClause(contribs []fkValues) whereClause {
+    var parts []string
+    var args []interface{}
+    n := 1
+    for _, c := range contribs {
+        if len(c.cols) == 1 {
+            placeholders := make([]string, len(c.rows))
+            for i, row := range c.rows {
+                placeholders[i] = fmt.Sprintf("$%d", n)
+                args = append(args, row[0])
+                n++
+            }
+            parts = append(parts, fmt.Sprintf("%s IN (%s)", quoteIdentifier(c.cols[0]), strings.Join(placeholders, ", ")))
+            continue
+        }
+        tuples := make([]string, len(c.rows))
+        for i, row := range c.rows {
+            cellPlaceholders := make([]string, len(row))
+            for j := range row {
+                cellPlaceholders[j] = fmt.Sprintf("$%d", n)
+                args = append(args, row[j])
+                n++
+            }
+            tuples[i] = "(" + strings.Join(cellPlaceholders, ", ") + ")"
+        }
+        parts = append(parts, fmt.Sprintf("(%s) IN (%s)", joinIdentifiers(append([]string(nil), c.cols...)), strings.Join(tuples, ", ")))
+    }
+    return whereClause{SQL: strings.Join(parts, " OR "), Args: args}
+}
+
+// scanColumnValues reads every row of n columns into a slice of
+// interface{} tuples, closing rows when done.
+func scanColumnValues(rows pgx.Rows, n int) ([][]interface{}, error) {
+    defer rows.Close()
+    var out [][]interface{}
+    for rows.Next() {
+        vals := make([]interface{}, n)
+        ptrs := make([]interface{}, n)
+        for i := range vals {
+            ptrs[i] = &vals[i]
+        }
+        if err := rows.Scan(ptrs...); err != nil {
+            return nil, fmt.Errorf("error scanning FK value row: %w", err)
+        }
+        out = append(out, vals)
+    }
+    return out, rows.Err()
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+    "reflect"
+    "testing"
+)
+
+func TestParseTableSpecs(t *testing.T) {
+    tests := []struct {
+        name    string
+        raw     []string
+        want    []tableSpec
+        wantErr bool
+    }{
+        {
+            name: "single table no where",
+            raw:  []string{"public.orders"},
+            want: []tableSpec{{schema: "public", table: "orders"}},
+        },
+        {
+            name: "comma separated with where",
+            raw:  []string{"public.orders:status = 'open',billing.invoices"},
+            want: []tableSpec{
+                {schema: "public", table: "orders", where: "status = 'open'"},
+                {schema: "billing", table: "invoices"},
+            },
+        },
+        {
+            name: "bare table name defaults to public schema",
+            raw:  []string{"orders"},
+            want: []tableSpec{{schema: "public", table: "orders"}},
+        },
+        {
+            name:    "no entries yields an error",
+            raw:     []string{" , "},
+            wantErr: true,
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := parseTableSpecs(tt.raw)
+            if tt.wantErr {
+                if err == nil {
+                    t.Fatalf("expected error, got none")
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("unexpected error: %v", err)
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("got %+v, want %+v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestBuildDependencyGraphAndTopologicalOrder(t *testing.T) {
+    specs := []tableSpec{
+        {schema: "public", table: "orders"},
+        {schema: "public", table: "customers"},
+        {schema: "public", table: "regions"},
+    }
+    fks := []fkConstraint{
+        {childSchema: "public", childTable: "orders", childCols: []string{"customer_id"}, parentSchema: "public", parentTable: "customers", parentCols: []string{"id"}},
+        {childSchema: "public", childTable: "customers", childCols: []string{"region_id"}, parentSchema: "public", parentTable: "regions", parentCols: []string{"id"}},
+        // References a table outside the --tables set; buildDependencyGraph
+        // should drop it rather than pulling warehouses into the graph.
+        {childSchema: "public", childTable: "orders", childCols: []string{"warehouse_id"}, parentSchema: "public", parentTable: "warehouses", parentCols: []string{"id"}},
+    }
+
+    parents, relevant := buildDependencyGraph(specs, fks)
+    if len(relevant) != 2 {
+        t.Fatalf("expected 2 relevant fks (warehouse edge dropped), got %d", len(relevant))
+    }
+
+    ordersRef := tableRef{schema: "public", table: "orders"}
+    customersRef := tableRef{schema: "public", table: "customers"}
+    regionsRef := tableRef{schema: "public", table: "regions"}
+
+    if !reflect.DeepEqual(parents[ordersRef], []tableRef{customersRef}) {
+        t.Errorf("orders parents = %v, want [%v]", parents[ordersRef], customersRef)
+    }
+    if !reflect.DeepEqual(parents[customersRef], []tableRef{regionsRef}) {
+        t.Errorf("customers parents = %v, want [%v]", parents[customersRef], regionsRef)
+    }
+    if len(parents[regionsRef]) != 0 {
+        t.Errorf("regions should have no parents, got %v", parents[regionsRef])
+    }
+
+    if hasCycle(specs, parents) {
+        t.Fatalf("expected no cycle among a linear chain")
+    }
+
+    order := topologicalOrder(specs, parents, nil)
+    pos := make(map[tableRef]int, len(order))
+    for i, ref := range order {
+        pos[ref] = i
+    }
+    if pos[regionsRef] > pos[customersRef] || pos[customersRef] > pos[ordersRef] {
+        t.Errorf("expected regions before customers before orders, got order %v", order)
+    }
+}
+
+func TestHasCycle(t *testing.T) {
+    a := tableRef{schema: "public", table: "a"}
+    b := tableRef{schema: "public", table: "b"}
+    specs := []tableSpec{{schema: "public", table: "a"}, {schema: "public", table: "b"}}
+    parents := map[tableRef][]tableRef{
+        a: {b},
+        b: {a},
+    }
+    if !hasCycle(specs, parents) {
+        t.Errorf("expected mutual FK references to be detected as a cycle")
+    }
+}
+
+func TestBuildInClause(t *testing.T) {
+    t.Run("single column", func(t *testing.T) {
+        wc := buildInClause([]fkValues{
+            {cols: []string{"id"}, rows: [][]interface{}{{1}, {2}}},
+        })
+        wantSQL := `"id" IN ($1, $2)`
+        if wc.SQL != wantSQL {
+            t.Errorf("SQL = %q, want %q", wc.SQL, wantSQL)
+        }
+        if !reflect.DeepEqual(wc.Args, []interface{}{1, 2}) {
+            t.Errorf("Args = %v, want [1 2]", wc.Args)
+        }
+    })
+
+    t.Run("composite key", func(t *testing.T) {
+        wc := buildInClause([]fkValues{
+            {cols: []string{"tenant_id", "id"}, rows: [][]interface{}{{1, "a"}}},
+        })
+        wantSQL := `("tenant_id", "id") IN (($1, $2))`
+        if wc.SQL != wantSQL {
+            t.Errorf("SQL = %q, want %q", wc.SQL, wantSQL)
+        }
+        if !reflect.DeepEqual(wc.Args, []interface{}{1, "a"}) {
+            t.Errorf("Args = %v, want [1 a]", wc.Args)
+        }
+    })
+
+    t.Run("multiple contributions OR together with continuous placeholder numbering", func(t *testing.T) {
+        wc := buildInClause([]fkValues{
+            {cols: []string{"id"}, rows: [][]interface{}{{1}}},
+            {cols: []string{"code"}, rows: [][]interface{}{{"x"}, {"y"}}},
+        })
+        wantSQL := `"id" IN ($1) OR "code" IN ($2, $3)`
+        if wc.SQL != wantSQL {
+            t.Errorf("SQL = %q, want %q", wc.SQL, wantSQL)
+        }
+        if !reflect.DeepEqual(wc.Args, []interface{}{1, "x", "y"}) {
+            t.Errorf("Args = %v, want [1 x y]", wc.Args)
+        }
+    })
+}